@@ -0,0 +1,177 @@
+// Command loadgen replays recorded search/proxy traffic against a Pinata instance and
+// reports throughput and latency, for checking performance work before and after.
+//
+// The input has one request per line: either a bare path ("/search?q=cats") or a Pinata
+// access log line ("... access <ip> GET /search?q=cats 200 ..."), so a log captured with
+// PINATA_LOG_PRIVACY set can be fed straight back in. Redacted query strings replay as-is.
+//
+//	go run ./cmd/loadgen -target http://127.0.0.1:8080 -c 16 -d 30s traffic.log
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type result struct {
+	status  int
+	bytes   int64
+	latency time.Duration
+	err     bool
+}
+
+func main() {
+	target := flag.String("target", "http://127.0.0.1:8080", "base URL of the instance under test")
+	conc := flag.Int("c", 8, "concurrent workers")
+	dur := flag.Duration("d", 30*time.Second, "how long to run (ignored with -n)")
+	total := flag.Int("n", 0, "stop after this many requests instead of -d")
+	skipLog := flag.String("skip-log-header", "X-Do-Not-Log", "header sent so the instance leaves replayed requests out of its access log (empty to disable)")
+	timeout := flag.Duration("timeout", 60*time.Second, "per-request timeout, body included")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: loadgen [flags] <traffic file, - for stdin>")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	paths, err := readPaths(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(paths) == 0 {
+		log.Fatal("no GET requests found in input")
+	}
+	base := strings.TrimRight(*target, "/")
+	client := &http.Client{
+		Timeout:   *timeout,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *conc, DisableCompression: true},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	var next atomic.Int64
+	deadline := time.Now().Add(*dur)
+	results := make(chan result, 1024)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range *conc {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := next.Add(1) - 1
+				if *total > 0 && i >= int64(*total) || *total == 0 && time.Now().After(deadline) {
+					return
+				}
+				results <- fire(client, base+paths[i%int64(len(paths))], *skipLog)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var lat []time.Duration
+	statuses := map[int]int{}
+	var bytes int64
+	errs := 0
+	for r := range results {
+		if r.err {
+			errs++
+			continue
+		}
+		statuses[r.status]++
+		bytes += r.bytes
+		lat = append(lat, r.latency)
+	}
+	report(time.Since(start), lat, statuses, bytes, errs)
+}
+
+// readPaths extracts GET request paths from bare paths or access log lines.
+func readPaths(name string) ([]string, error) {
+	var in io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+	var paths []string
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "/") {
+			paths = append(paths, strings.Fields(line)[0])
+			continue
+		}
+		fields := strings.Fields(line)
+		for i, f := range fields {
+			if f == "access" && i+3 < len(fields) {
+				if fields[i+2] == http.MethodGet && strings.HasPrefix(fields[i+3], "/") {
+					paths = append(paths, fields[i+3])
+				}
+				break
+			}
+		}
+	}
+	return paths, sc.Err()
+}
+
+func fire(client *http.Client, u, skipLog string) result {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return result{err: true}
+	}
+	if skipLog != "" {
+		req.Header.Set(skipLog, "1")
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return result{err: true}
+	}
+	n, err := io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return result{err: true}
+	}
+	return result{status: resp.StatusCode, bytes: n, latency: time.Since(start)}
+}
+
+func report(elapsed time.Duration, lat []time.Duration, statuses map[int]int, bytes int64, errs int) {
+	sort.Slice(lat, func(i, j int) bool { return lat[i] < lat[j] })
+	pct := func(p float64) time.Duration {
+		if len(lat) == 0 {
+			return 0
+		}
+		return lat[int(p*float64(len(lat)-1))].Round(100 * time.Microsecond)
+	}
+	secs := elapsed.Seconds()
+	fmt.Printf("requests  %d in %s (%.1f/s), %d transport errors\n", len(lat), elapsed.Round(time.Millisecond), float64(len(lat))/secs, errs)
+	fmt.Printf("received  %.1f MiB (%.2f MiB/s)\n", float64(bytes)/(1<<20), float64(bytes)/(1<<20)/secs)
+	fmt.Printf("latency   p50 %s  p90 %s  p99 %s  max %s\n", pct(0.5), pct(0.9), pct(0.99), pct(1))
+	codes := make([]int, 0, len(statuses))
+	for c := range statuses {
+		codes = append(codes, c)
+	}
+	sort.Ints(codes)
+	for _, c := range codes {
+		fmt.Printf("status    %d  %d\n", c, statuses[c])
+	}
+}
@@ -1,3 +1,7 @@
 module codeberg.org/gigirassy/pinata
 
-go 1.26.0
\ No newline at end of file
+go 1.26.0
+
+require golang.org/x/crypto v0.57.0
+
+require golang.org/x/sys v0.48.0 // indirect
@@ -0,0 +1,136 @@
+// Package token makes the small signed values Pinata hands to browsers and other services:
+// compact, URL-safe, versioned and carrying their own expiry, so any replica that shares the
+// secret can check one without a shared store.
+//
+// A Keyring is bound to one purpose; its keys are derived from the instance secret and the
+// purpose name, so a token minted for one feature is never accepted by another.
+//
+// Two formats exist. Sign leaves the payload readable and only authenticates it (HMAC-SHA256,
+// truncated to 128 bits); Seal also encrypts it (AES-256-GCM), for values the holder should
+// not read, such as upstream session tokens carried in links.
+package token
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const (
+	versionSigned byte = 1
+	versionSealed byte = 2
+	macSize            = 16
+)
+
+var (
+	// ErrInvalid covers every malformed, forged or wrong-purpose token.
+	ErrInvalid = errors.New("token: invalid")
+	// ErrExpired is returned for an authentic token past its expiry.
+	ErrExpired = errors.New("token: expired")
+)
+
+var enc = base64.RawURLEncoding
+
+// Keyring signs and seals tokens for one purpose.
+type Keyring struct {
+	mac  []byte
+	aead cipher.AEAD
+}
+
+// New derives a purpose's keys from secret, which should be at least 32 random bytes.
+func New(secret []byte, purpose string) *Keyring {
+	block, err := aes.NewCipher(derive(secret, "seal:"+purpose))
+	if err != nil {
+		panic(err) // a 32-byte key is always valid
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &Keyring{mac: derive(secret, "sign:"+purpose), aead: aead}
+}
+
+func derive(secret []byte, label string) []byte {
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte("pinata token " + label))
+	return m.Sum(nil)
+}
+
+// header is version || expiry (unix seconds, uvarint; 0 means none).
+func header(version byte, ttl time.Duration) []byte {
+	var exp uint64
+	if ttl > 0 {
+		exp = uint64(time.Now().Add(ttl).Unix())
+	}
+	b := make([]byte, 1, 1+binary.MaxVarintLen64)
+	b[0] = version
+	return binary.AppendUvarint(b, exp)
+}
+
+func checkExpiry(b []byte) ([]byte, error) {
+	exp, n := binary.Uvarint(b)
+	if n <= 0 {
+		return nil, ErrInvalid
+	}
+	if exp != 0 && uint64(time.Now().Unix()) > exp {
+		return nil, ErrExpired
+	}
+	return b[n:], nil
+}
+
+// Sign returns a token carrying payload in the clear. A ttl of 0 never expires.
+func (k *Keyring) Sign(payload []byte, ttl time.Duration) string {
+	body := append(header(versionSigned, ttl), payload...)
+	return enc.EncodeToString(append(body, k.tag(body)...))
+}
+
+func (k *Keyring) tag(body []byte) []byte {
+	m := hmac.New(sha256.New, k.mac)
+	m.Write(body)
+	return m.Sum(nil)[:macSize]
+}
+
+// Verify returns the payload of a token made by Sign with the same purpose.
+func (k *Keyring) Verify(tok string) ([]byte, error) {
+	raw, err := enc.DecodeString(tok)
+	if err != nil || len(raw) < 2+macSize || raw[0] != versionSigned {
+		return nil, ErrInvalid
+	}
+	body, tag := raw[:len(raw)-macSize], raw[len(raw)-macSize:]
+	if !hmac.Equal(tag, k.tag(body)) {
+		return nil, ErrInvalid
+	}
+	return checkExpiry(body[1:])
+}
+
+// Seal returns a token whose payload only holders of the secret can read.
+func (k *Keyring) Seal(payload []byte, ttl time.Duration) string {
+	h := header(versionSealed, ttl)
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err)
+	}
+	out := append([]byte{versionSealed}, nonce...)
+	out = k.aead.Seal(out, nonce, append(h[1:], payload...), out[:1])
+	return enc.EncodeToString(out)
+}
+
+// Open returns the payload of a token made by Seal with the same purpose.
+func (k *Keyring) Open(tok string) ([]byte, error) {
+	raw, err := enc.DecodeString(tok)
+	ns := k.aead.NonceSize()
+	if err != nil || len(raw) < 1+ns+k.aead.Overhead() || raw[0] != versionSealed {
+		return nil, ErrInvalid
+	}
+	plain, err := k.aead.Open(nil, raw[1:1+ns], raw[1+ns:], raw[:1])
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	return checkExpiry(plain)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+// benchSearchBody builds an upstream search response shaped like BaseSearchResource's.
+func benchSearchBody(n int) []byte {
+	type orig struct {
+		URL    string `json:"url"`
+		Width  int    `json:"width"`
+		Height int    `json:"height"`
+	}
+	results := make([]map[string]any, n)
+	for i := range results {
+		results[i] = map[string]any{
+			"id":          fmt.Sprint(100000000 + i),
+			"grid_title":  fmt.Sprintf("Pin %d", i),
+			"description": "A fairly ordinary pin description with a few words in it.",
+			"images": map[string]any{
+				"236x": orig{URL: fmt.Sprintf("https://i.pinimg.com/236x/ab/cd/ef/abcdef%06d.jpg", i), Width: 236, Height: 354},
+				"orig": orig{URL: fmt.Sprintf("https://i.pinimg.com/originals/ab/cd/ef/abcdef%06d.jpg", i), Width: 1000, Height: 1500},
+			},
+			"pinner": map[string]any{"username": "someone", "full_name": "Some One"},
+		}
+	}
+	body, _ := json.Marshal(map[string]any{
+		"resource_response": map[string]any{
+			"data":     map[string]any{"results": results},
+			"bookmark": "Y2JVSG81V2sxcmNHRlp",
+		},
+	})
+	return body
+}
+
+func BenchmarkStreamSearchResults(b *testing.B) {
+	body := benchSearchBody(50)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for b.Loop() {
+		n := 0
+		streamSearchResults(bytes.NewReader(body), func(searchResult) { n++ })
+		if n != 50 {
+			b.Fatalf("got %d results", n)
+		}
+	}
+}
+
+func BenchmarkRenderCardHTML(b *testing.B) {
+	u := "https://i.pinimg.com/originals/ab/cd/ef/abcdef000001.jpg"
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = renderCardHTML(1, "/search?q=cats", u, false, 236, 474, 736)
+	}
+}
+
+func BenchmarkWriteChunkedCards(b *testing.B) {
+	results := make([]searchResult, chunkSize)
+	for i := range results {
+		results[i] = searchResult{Image: fmt.Sprintf("https://i.pinimg.com/originals/ab/cd/ef/abcdef%06d.jpg", i)}
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		writeChunkedCards(httptest.NewRecorder(), 1, "/search?q=cats", "", results, 236, 474, 736)
+	}
+}
+
+func benchBookmarks(n int) []BookmarkEntry {
+	entries := make([]BookmarkEntry, n)
+	for i := range entries {
+		entries[i] = BookmarkEntry{Type: "img", Value: fmt.Sprintf("https://i.pinimg.com/originals/ab/cd/ef/abcdef%06d.jpg", i), Folder: "Recipes"}
+	}
+	return entries
+}
+
+func withBenchKey(b *testing.B) {
+	old := bookmarkKey
+	bookmarkKey = bytes.Repeat([]byte{7}, 32)
+	b.Cleanup(func() { bookmarkKey = old })
+}
+
+func BenchmarkEncryptBookmarks(b *testing.B) {
+	withBenchKey(b)
+	entries := benchBookmarks(50)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := encryptBookmarks(entries); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecryptBookmarks(b *testing.B) {
+	withBenchKey(b)
+	enc, err := encryptBookmarks(benchBookmarks(50))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(enc)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := decryptBookmarks(enc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}